@@ -3,14 +3,19 @@ package main
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
-	"strings"
+	"strconv"
+	"time"
+
+	"github.com/unblee/rat/internal/copyfs"
 )
 
 // CLI is the command line object
 type CLI struct {
+	stdin    io.Reader
 	stdout   io.Writer
 	stderr   io.Writer
 	fatalLog *log.Logger
@@ -23,6 +28,7 @@ func newCLI(stdout, stderr io.Writer, args []string) (*CLI, error) {
 		return nil, err
 	}
 	return &CLI{
+		stdin:    os.Stdin,
 		stdout:   stdout,
 		stderr:   stderr,
 		fatalLog: newFatalLogger(stderr),
@@ -36,13 +42,29 @@ func newFatalLogger(stderr io.Writer) *log.Logger {
 
 // main process
 func (c *CLI) run() int {
+	if c.cfg.verboseVersion {
+		fmt.Fprint(c.stdout, versionBanner(c.cfg))
+		return exitCodeOK
+	}
+	if c.cfg.showVersion {
+		fmt.Fprintf(c.stdout, "rat version %s\n", version)
+		return exitCodeOK
+	}
+	if c.cfg.configSubcommand != "" {
+		return c.runConfigSubcommand()
+	}
+	if c.cfg.addSource != "" {
+		return c.addBoilerplate()
+	}
+	if c.cfg.updateName != "" {
+		return c.updateBoilerplate()
+	}
 	if c.cfg.showList {
 		return c.outputList()
 	}
 
-	// copy boilerplate-name to project-name
-	err := c.copyDir()
-	if err != nil {
+	// generate project-name from boilerplate-name
+	if err := c.generate(); err != nil {
 		c.fatalLog.Println(err)
 		return exitCodeError
 	}
@@ -50,6 +72,50 @@ func (c *CLI) run() int {
 	return exitCodeOK
 }
 
+// runConfigSubcommand handles `rat config init` and `rat config show`
+func (c *CLI) runConfigSubcommand() int {
+	switch c.cfg.configSubcommand {
+	case "init":
+		path, err := writeStarterFileConfig()
+		if err != nil {
+			c.fatalLog.Println(err)
+			return exitCodeError
+		}
+		fmt.Fprintf(c.stdout, "wrote '%s'\n", path)
+		return exitCodeOK
+	case "show":
+		fmt.Fprintf(c.stdout, "root = %q\n", c.cfg.root)
+		fmt.Fprintf(c.stdout, "roots = %q\n", c.cfg.roots)
+		fmt.Fprintf(c.stdout, "filter_profile = %q\n", c.cfg.filterProfile)
+		fmt.Fprintf(c.stdout, "filter = %q\n", c.cfg.filter)
+		return exitCodeOK
+	default:
+		c.fatalLog.Printf("Unknown 'config %s' subcommand", c.cfg.configSubcommand)
+		return exitCodeError
+	}
+}
+
+// addBoilerplate fetches a remote boilerplate into the cache without
+// generating a project from it
+func (c *CLI) addBoilerplate() int {
+	if _, err := fetchBoilerplate(c.cfg.root, c.cfg.addSource, c.cfg.checksum, false); err != nil {
+		c.fatalLog.Println(err)
+		return exitCodeError
+	}
+	fmt.Fprintf(c.stdout, "added '%s'\n", c.cfg.addSource)
+	return exitCodeOK
+}
+
+// updateBoilerplate re-fetches an already cached remote boilerplate
+func (c *CLI) updateBoilerplate() int {
+	if _, err := fetchBoilerplate(c.cfg.root, c.cfg.updateName, c.cfg.checksum, true); err != nil {
+		c.fatalLog.Println(err)
+		return exitCodeError
+	}
+	fmt.Fprintf(c.stdout, "updated '%s'\n", c.cfg.updateName)
+	return exitCodeOK
+}
+
 func (c *CLI) outputList() int {
 	blist, err := c.cfg.blplList()
 	if err != nil {
@@ -64,50 +130,107 @@ func (c *CLI) outputList() int {
 	return exitCodeOK
 }
 
-func (c *CLI) copyDir() error {
+// generate resolves the boilerplate's template variables and copies it to
+// the destination project path, expanding templates as it goes
+func (c *CLI) generate() error {
 	dst := c.cfg.projectPath
-	src := filepath.Join(c.cfg.root, c.cfg.boilerplateName)
-	if !fileExists(src) {
-		c.fatalLog.Printf("Not exists directory '%s'", src)
+
+	var src string
+	if isRemoteSource(c.cfg.boilerplateName) {
+		fetched, err := fetchBoilerplate(c.cfg.root, c.cfg.boilerplateName, c.cfg.checksum, false)
+		if err != nil {
+			return err
+		}
+		src = fetched
+	} else {
+		found, err := c.cfg.findBoilerplate(c.cfg.boilerplateName)
+		if err != nil {
+			return err
+		}
+		src = found
 	}
 
+	manifest, err := loadManifest(src)
+	if err != nil {
+		return err
+	}
+
+	vars, err := manifest.resolveVars(c.cfg.vars, c.stdin, c.stderr)
+	if err != nil {
+		return err
+	}
+	mergeBuiltinVars(vars, dst)
+
+	// snapshot whether dst was empty before pre-hooks run, so that content a
+	// hook creates itself (e.g. a "git-init" macro) isn't later mistaken by
+	// copyDir for pre-existing content and rejected
+	dstWasEmpty := dirIsEmpty(dst)
+
+	// pre-hooks run with the project directory as cwd, so it must exist first
 	os.Mkdir(dst, 0755)
-	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
-		// e.g.
-		// src  = /home/foo
-		// path = /home/foo/bar
-		//                 /bar
-		path = strings.TrimPrefix(path, src)
-
-		// skip src root dir
-		if path == "" {
-			return nil
+
+	env := hookEnv(dst, vars)
+	if !c.cfg.noHooks {
+		if err := runHooks(manifest.PreHooks, dst, env, c.cfg.dryRun, c.stdout, c.stderr); err != nil {
+			return err
 		}
+	}
 
-		if info.IsDir() { // make dest dir
-			dstDir := filepath.Join(dst, path)
-			err := os.Mkdir(dstDir, info.Mode())
-			if err != nil {
-				return err
-			}
-		} else { // copy file
-			srcFile, err := os.Open(src)
-			if err != nil {
-				return err
-			}
-			defer srcFile.Close()
+	if err := c.copyDir(dst, src, manifest, vars, dstWasEmpty); err != nil {
+		return err
+	}
 
-			dstFile, err := os.Create(filepath.Join(dst, path))
-			if err != nil {
-				return err
-			}
-			defer dstFile.Close()
+	if !c.cfg.noHooks {
+		if err := runHooks(manifest.PostHooks, dst, env, c.cfg.dryRun, c.stdout, c.stderr); err != nil {
+			return err
+		}
+	}
 
-			// TODO: hook to find template
+	return nil
+}
 
-			io.Copy(dstFile, srcFile)
-		}
+// mergeBuiltinVars fills in the variables rat derives automatically,
+// without overriding anything the user already supplied or was prompted for
+func mergeBuiltinVars(vars map[string]string, projectPath string) {
+	if _, ok := vars["ProjectName"]; !ok {
+		vars["ProjectName"] = filepath.Base(projectPath)
+	}
+	if _, ok := vars["Year"]; !ok {
+		vars["Year"] = strconv.Itoa(time.Now().Year())
+	}
+}
 
-		return nil
-	})
+// dirIsEmpty reports whether path has no entries, treating a path that
+// doesn't exist yet as empty
+func dirIsEmpty(path string) bool {
+	entries, err := ioutil.ReadDir(path)
+	if err != nil {
+		return true
+	}
+	return len(entries) == 0
+}
+
+// copyDir copies src to dst, expanding template names and contents as it
+// goes and skipping the boilerplate's own manifest file. dstWasEmpty forces
+// the copy even when dst is no longer empty, since pre-hooks are allowed to
+// populate dst before the boilerplate itself is copied in.
+func (c *CLI) copyDir(dst, src string, manifest *Manifest, vars map[string]string, dstWasEmpty bool) error {
+	copier := &copyfs.Copier{
+		Src:   src,
+		Dst:   dst,
+		Force: c.cfg.force || dstWasEmpty,
+		Skip: func(relPath string) bool {
+			return relPath == manifestFileName
+		},
+		Rename: func(relPath string) (string, error) {
+			return renderName(relPath, vars)
+		},
+		Transform: func(relPath string, content []byte) ([]byte, error) {
+			if isIgnoredFile(filepath.Base(relPath), manifest.Ignore) {
+				return content, nil
+			}
+			return renderTemplate(relPath, content, vars)
+		},
+	}
+	return copier.Copy()
 }
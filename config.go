@@ -13,58 +13,184 @@ import (
 	"runtime"
 	"strings"
 
-	"github.com/mitchellh/go-homedir"
+	homedir "github.com/mitchellh/go-homedir"
 )
 
 // Config is the command line config
 type Config struct {
 	showList        bool
 	root            string
+	roots           []string
+	extraRoots      []string
+	filterProfile   string
 	filter          string
+	filterEnv       []string
 	boilerplateName string
 	projectPath     string
+	vars            map[string]string
+	noHooks         bool
+	dryRun          bool
+	addSource       string
+	updateName      string
+	checksum        string
+	force           bool
+	showVersion     bool
+	verboseVersion  bool
+
+	// configSubcommand holds the subcommand name for `rat config <name>`,
+	// e.g. "init" or "show". it is empty for normal invocations.
+	configSubcommand string
+}
+
+// varsFlag collects repeated `-var key=value` flags into a map
+type varsFlag map[string]string
+
+func (v varsFlag) String() string {
+	return fmt.Sprintf("%v", map[string]string(v))
+}
+
+func (v varsFlag) Set(s string) error {
+	kv := strings.SplitN(s, "=", 2)
+	if len(kv) != 2 || kv[0] == "" {
+		return fmt.Errorf("invalid -var '%s', expected 'key=value'", s)
+	}
+	v[kv[0]] = kv[1]
+	return nil
+}
+
+// parseVars parses the "key=value,key2=value2" format used by RAT_VARS
+func parseVars(s string) (map[string]string, error) {
+	vars := make(map[string]string)
+	if s == "" {
+		return vars, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("invalid RAT_VARS entry '%s', expected 'key=value'", pair)
+		}
+		vars[kv[0]] = kv[1]
+	}
+	return vars, nil
 }
 
 // set options, environment values and arguments to Config
 func loadConfig(stdout, errStream io.Writer, args []string) (*Config, error) {
 	cfg := new(Config)
 
+	// `rat config init` / `rat config show` and `rat version` are routed
+	// separately from boilerplate generation, but still want the resolved
+	// root/filter below
+	if len(args) >= 2 && args[1] == "config" {
+		cfg.configSubcommand = "show"
+		if len(args) >= 3 {
+			cfg.configSubcommand = args[2]
+		}
+	}
+	if len(args) >= 2 && args[1] == "version" {
+		cfg.verboseVersion = true
+	}
+
 	flags := flag.NewFlagSet(NAME, flag.ContinueOnError)
 	flags.SetOutput(stdout)
 
 	// set help text
 	flags.Usage = func() {
-		fmt.Fprintln(stdout, helpText)
+		fmt.Fprint(stdout, helpText)
 		os.Exit(exitCodeOK)
 	}
 
-	// set a default boilerplates root directory
-	home, err := homedir.Dir()
+	fileCfg, err := loadFileConfig()
 	if err != nil {
-		return nil, errors.New("failed to get a home directory path")
+		return nil, err
 	}
-	defaultRoot := filepath.Join(home, ".rat")
-
-	// set filter command to be used by default
-	defaultFilter := "peco"
 
-	// set the command line options
-	var showVersion bool
+	// set the command line options. flags default to "" / false so that
+	// flags.Visit can later tell us whether a flag was actually passed,
+	// which CLI flag > env > config file > default precedence needs.
+	cfg.vars = make(varsFlag)
 	flags.BoolVar(&cfg.showList, "list", false, "")
 	flags.BoolVar(&cfg.showList, "l", false, "")
-	flags.StringVar(&cfg.root, "root", defaultRoot, "")
-	flags.StringVar(&cfg.filter, "filter", defaultFilter, "")
-	flags.BoolVar(&showVersion, "version", false, "")
-	flags.BoolVar(&showVersion, "v", false, "")
+	flags.StringVar(&cfg.root, "root", "", "")
+	flags.StringVar(&cfg.filterProfile, "filter-profile", "", "")
+	flags.StringVar(&cfg.filter, "filter", "", "")
+	flags.Var(varsFlag(cfg.vars), "var", "")
+	flags.BoolVar(&cfg.noHooks, "no-hooks", false, "")
+	flags.BoolVar(&cfg.dryRun, "dry-run", false, "")
+	flags.StringVar(&cfg.addSource, "add", "", "")
+	flags.StringVar(&cfg.updateName, "update", "", "")
+	flags.StringVar(&cfg.checksum, "checksum", "", "")
+	flags.BoolVar(&cfg.force, "force", false, "")
+	flags.BoolVar(&cfg.showVersion, "version", false, "")
+	flags.BoolVar(&cfg.showVersion, "v", false, "")
 	flags.Parse(args[1:])
 
-	if showVersion {
-		fmt.Fprintf(stdout, "rat version %s\n", VERSION)
+	set := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	// -- root: flag > RAT_ROOT > config file > default
+	switch {
+	case set["root"]:
+		// cfg.root already holds the flag value
+	case os.Getenv("RAT_ROOT") != "":
+		cfg.root = os.Getenv("RAT_ROOT")
+	case fileCfg.Root != "":
+		cfg.root = fileCfg.Root
+	default:
+		home, err := homedir.Dir()
+		if err != nil {
+			return nil, errors.New("failed to get a home directory path")
+		}
+		cfg.root = filepath.Join(home, ".rat")
+	}
+	cfg.extraRoots = fileCfg.Roots
+
+	// -- filter profile: flag > RAT_FILTER_PROFILE > config file > default
+	switch {
+	case set["filter-profile"]:
+		// cfg.filterProfile already holds the flag value
+	case os.Getenv("RAT_FILTER_PROFILE") != "":
+		cfg.filterProfile = os.Getenv("RAT_FILTER_PROFILE")
+	case fileCfg.FilterProfile != "":
+		cfg.filterProfile = fileCfg.FilterProfile
+	default:
+		cfg.filterProfile = "peco"
+	}
+
+	// -- filter command: flag > RAT_FILTER > profile from config file > default
+	switch {
+	case set["filter"]:
+		// cfg.filter already holds the flag value
+	case os.Getenv("RAT_FILTER") != "":
+		cfg.filter = os.Getenv("RAT_FILTER")
+	default:
+		profile, ok := fileCfg.Filters[cfg.filterProfile]
+		if !ok {
+			profile, ok = defaultFileConfig.Filters[cfg.filterProfile]
+		}
+		if ok {
+			cfg.filter = profile.commandLine()
+			cfg.filterEnv = profile.envSlice()
+		} else {
+			cfg.filter = cfg.filterProfile
+		}
+	}
+
+	if envVars, err := parseVars(os.Getenv("RAT_VARS")); err != nil {
+		return nil, err
+	} else {
+		for k, v := range envVars {
+			if _, ok := cfg.vars[k]; !ok {
+				cfg.vars[k] = v
+			}
+		}
 	}
 
-	// set environment values
-	cfg.root = os.Getenv("RAT_ROOT")
-	cfg.filter = os.Getenv("RAT_FILTER")
+	if cfg.configSubcommand != "" || cfg.verboseVersion || cfg.showVersion {
+		cfg.root = expandRoot(cfg.root)
+		cfg.roots = mergeRoots(cfg.root, cfg.extraRoots)
+		return cfg, nil
+	}
 
 	// set arguments
 	switch flags.NArg() {
@@ -89,20 +215,46 @@ func loadConfig(stdout, errStream io.Writer, args []string) (*Config, error) {
 	return cfg, nil
 }
 
+// expandRoot resolves "~" and environment variables in root and trims a
+// trailing path separator
+func expandRoot(root string) string {
+	expanded, err := homedir.Expand(root)
+	if err != nil {
+		return root
+	}
+	expanded = os.ExpandEnv(expanded)
+	return strings.TrimSuffix(expanded, string(filepath.Separator))
+}
+
+// mergeRoots combines the primary root with any additional roots declared
+// in the config file, deduplicating while preserving order
+func mergeRoots(root string, extra []string) []string {
+	roots := make([]string, 0, 1+len(extra))
+	seen := make(map[string]bool)
+	for _, r := range append([]string{root}, extra...) {
+		if r == "" || seen[r] {
+			continue
+		}
+		seen[r] = true
+		roots = append(roots, r)
+	}
+	return roots
+}
+
+// commandLine renders a FilterProfile into the shell command line rat runs
+func (p FilterProfile) commandLine() string {
+	parts := append([]string{p.Command}, p.Args...)
+	return strings.Join(parts, " ")
+}
+
 // config validation
 func (cfg *Config) validate() error {
 	// -- ratRoot validation
-	// expand path
 	if cfg.root == "" {
 		return errors.New("Please set 'RAT_ROOT' environment value")
 	}
-	ratRoot, err := homedir.Expand(cfg.root)
-	if err != nil {
-		return err
-	}
-	ratRoot = os.ExpandEnv(ratRoot)
-	// delete the suffix directory separator to unify the handling of the path
-	cfg.root = strings.TrimSuffix(ratRoot, string(filepath.Separator))
+	cfg.root = expandRoot(cfg.root)
+	cfg.roots = mergeRoots(cfg.root, cfg.extraRoots)
 
 	// -- boilerplateName validation
 	if cfg.hasExecFilter() {
@@ -110,8 +262,8 @@ func (cfg *Config) validate() error {
 		if cfg.filter == "" {
 			return errors.New("Please set 'RAT_FILTER' environment value")
 		}
-		if !cmdExists(cfg.filter) {
-			return fmt.Errorf("Not exists '%s' command", cfg.filter)
+		if !cmdExists(firstWord(cfg.filter)) {
+			return fmt.Errorf("Not exists '%s' command", firstWord(cfg.filter))
 		}
 
 		boilerplateName, err := cfg.filterBlpl()
@@ -134,24 +286,39 @@ func (cfg *Config) validate() error {
 // returns true if options and boilerplate name are not specified.
 // that is, filter command is executed.
 func (cfg *Config) hasExecFilter() bool {
+	if cfg.showList || cfg.addSource != "" || cfg.updateName != "" {
+		return false
+	}
 	return cfg.boilerplateName == ""
 }
 
-// list of boilerplate directries
-func (cfg *Config) blplList() ([]string, error) {
-	// ls ratRoot
-	dirs, err := ioutil.ReadDir(cfg.root)
-	if err != nil {
-		return nil, err
+// findBoilerplate searches every configured root, primary root first, for a
+// directory named name and returns the first match
+func (cfg *Config) findBoilerplate(name string) (string, error) {
+	for _, root := range cfg.roots {
+		path := filepath.Join(root, name)
+		if fileExists(path) {
+			return path, nil
+		}
 	}
+	return "", fmt.Errorf("Not exists directory '%s' in any configured root", name)
+}
 
-	if len(dirs) == 0 {
-		return nil, errors.New("Not exists boilerplate directories")
+// list of boilerplate names across every configured root
+func (cfg *Config) blplList() ([]string, error) {
+	var list []string
+	for _, root := range cfg.roots {
+		dirs, err := ioutil.ReadDir(root)
+		if err != nil {
+			continue
+		}
+		for _, d := range dirs {
+			list = append(list, d.Name())
+		}
 	}
 
-	list := make([]string, len(dirs))
-	for i := 0; i < len(dirs); i++ {
-		list[i] = dirs[i].Name()
+	if len(list) == 0 {
+		return nil, errors.New("Not exists boilerplate directories")
 	}
 	return list, nil
 }
@@ -182,12 +349,22 @@ func (cfg *Config) runFilter(r io.Reader, w io.Writer) error {
 	} else {
 		cmd = exec.Command("sh", "-c", cfg.filter)
 	}
+	if len(cfg.filterEnv) > 0 {
+		cmd.Env = append(os.Environ(), cfg.filterEnv...)
+	}
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = r
 	cmd.Stdout = w
 	return cmd.Run()
 }
 
+func firstWord(s string) string {
+	if i := strings.IndexByte(s, ' '); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
 func fileExists(filepath string) bool {
 	_, err := os.Stat(filepath)
 	return err == nil
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// withCleanEnv clears the env vars loadConfig consults and points
+// XDG_CONFIG_HOME at an empty temp dir, so a test only sees what it sets up
+// itself rather than whatever happens to be on the host running the tests.
+func withCleanEnv(t *testing.T) {
+	t.Helper()
+	for _, k := range []string{"RAT_ROOT", "RAT_FILTER_PROFILE", "RAT_FILTER", "RAT_VARS", "XDG_CONFIG_HOME"} {
+		old, had := os.LookupEnv(k)
+		os.Unsetenv(k)
+		t.Cleanup(func() {
+			if had {
+				os.Setenv(k, old)
+			}
+		})
+	}
+
+	xdg, err := ioutil.TempDir("", "rat-xdg-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(xdg) })
+	os.Setenv("XDG_CONFIG_HOME", xdg)
+}
+
+func writeConfigFile(t *testing.T, xdg, content string) {
+	t.Helper()
+	dir := filepath.Join(xdg, NAME)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, configFileName), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadConfigRootDefaultsUnderHome(t *testing.T) {
+	withCleanEnv(t)
+
+	var stdout, stderr bytes.Buffer
+	cfg, err := loadConfig(&stdout, &stderr, []string{"rat", "bp", "project"})
+	if err != nil {
+		t.Fatalf("loadConfig returned an error: %s", err)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := filepath.Join(home, ".rat")
+	if cfg.root != want {
+		t.Fatalf("root = %q, want %q", cfg.root, want)
+	}
+}
+
+func TestLoadConfigRootFromConfigFile(t *testing.T) {
+	withCleanEnv(t)
+	writeConfigFile(t, os.Getenv("XDG_CONFIG_HOME"), `root = "/from/file"`)
+
+	var stdout, stderr bytes.Buffer
+	cfg, err := loadConfig(&stdout, &stderr, []string{"rat", "bp", "project"})
+	if err != nil {
+		t.Fatalf("loadConfig returned an error: %s", err)
+	}
+	if cfg.root != "/from/file" {
+		t.Fatalf("root = %q, want %q", cfg.root, "/from/file")
+	}
+}
+
+func TestLoadConfigRootEnvOverridesConfigFile(t *testing.T) {
+	withCleanEnv(t)
+	writeConfigFile(t, os.Getenv("XDG_CONFIG_HOME"), `root = "/from/file"`)
+	os.Setenv("RAT_ROOT", "/from/env")
+
+	var stdout, stderr bytes.Buffer
+	cfg, err := loadConfig(&stdout, &stderr, []string{"rat", "bp", "project"})
+	if err != nil {
+		t.Fatalf("loadConfig returned an error: %s", err)
+	}
+	if cfg.root != "/from/env" {
+		t.Fatalf("root = %q, want %q", cfg.root, "/from/env")
+	}
+}
+
+func TestLoadConfigRootFlagOverridesEnv(t *testing.T) {
+	withCleanEnv(t)
+	os.Setenv("RAT_ROOT", "/from/env")
+
+	var stdout, stderr bytes.Buffer
+	cfg, err := loadConfig(&stdout, &stderr, []string{"rat", "--root", "/from/flag", "bp", "project"})
+	if err != nil {
+		t.Fatalf("loadConfig returned an error: %s", err)
+	}
+	if cfg.root != "/from/flag" {
+		t.Fatalf("root = %q, want %q", cfg.root, "/from/flag")
+	}
+}
+
+func TestLoadConfigRootsIncludesExtraRootsFromConfigFile(t *testing.T) {
+	withCleanEnv(t)
+	writeConfigFile(t, os.Getenv("XDG_CONFIG_HOME"), `root = "/primary"
+roots = ["/secondary", "/primary"]
+`)
+
+	var stdout, stderr bytes.Buffer
+	cfg, err := loadConfig(&stdout, &stderr, []string{"rat", "bp", "project"})
+	if err != nil {
+		t.Fatalf("loadConfig returned an error: %s", err)
+	}
+	want := []string{"/primary", "/secondary"}
+	if len(cfg.roots) != len(want) {
+		t.Fatalf("roots = %v, want %v", cfg.roots, want)
+	}
+	for i := range want {
+		if cfg.roots[i] != want[i] {
+			t.Fatalf("roots = %v, want %v", cfg.roots, want)
+		}
+	}
+}
+
+func TestLoadConfigFilterFromProfile(t *testing.T) {
+	withCleanEnv(t)
+	writeConfigFile(t, os.Getenv("XDG_CONFIG_HOME"), `root = "/primary"
+filter_profile = "fzf"
+
+[filters.fzf]
+command = "fzf"
+args = ["--height=40%"]
+[filters.fzf.env]
+FZF_DEFAULT_OPTS = "--layout=reverse"
+`)
+
+	var stdout, stderr bytes.Buffer
+	cfg, err := loadConfig(&stdout, &stderr, []string{"rat", "bp", "project"})
+	if err != nil {
+		t.Fatalf("loadConfig returned an error: %s", err)
+	}
+	if cfg.filter != "fzf --height=40%" {
+		t.Fatalf("filter = %q, want %q", cfg.filter, "fzf --height=40%")
+	}
+	if len(cfg.filterEnv) != 1 || cfg.filterEnv[0] != "FZF_DEFAULT_OPTS=--layout=reverse" {
+		t.Fatalf("filterEnv = %v, want [\"FZF_DEFAULT_OPTS=--layout=reverse\"]", cfg.filterEnv)
+	}
+}
+
+func TestLoadConfigFilterEnvOverridesProfile(t *testing.T) {
+	withCleanEnv(t)
+	os.Setenv("RAT_FILTER", "custom-filter")
+
+	var stdout, stderr bytes.Buffer
+	cfg, err := loadConfig(&stdout, &stderr, []string{"rat", "bp", "project"})
+	if err != nil {
+		t.Fatalf("loadConfig returned an error: %s", err)
+	}
+	if cfg.filter != "custom-filter" {
+		t.Fatalf("filter = %q, want %q", cfg.filter, "custom-filter")
+	}
+	if len(cfg.filterEnv) != 0 {
+		t.Fatalf("filterEnv = %v, want none", cfg.filterEnv)
+	}
+}
+
+func TestMergeRootsDedupesPreservingOrder(t *testing.T) {
+	got := mergeRoots("/a", []string{"/b", "/a", "/c", ""})
+	want := []string{"/a", "/b", "/c"}
+	if len(got) != len(want) {
+		t.Fatalf("mergeRoots = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("mergeRoots = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestFilterProfileCommandLine(t *testing.T) {
+	p := FilterProfile{Command: "gum", Args: []string{"choose", "--height=10"}}
+	want := "gum choose --height=10"
+	if got := p.commandLine(); got != want {
+		t.Fatalf("commandLine() = %q, want %q", got, want)
+	}
+}
+
+func TestFilterProfileEnvSlice(t *testing.T) {
+	p := FilterProfile{Command: "fzf", Env: map[string]string{"FOO": "bar"}}
+	got := p.envSlice()
+	if len(got) != 1 || got[0] != "FOO=bar" {
+		t.Fatalf("envSlice() = %v, want [\"FOO=bar\"]", got)
+	}
+}
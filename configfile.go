@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+	homedir "github.com/mitchellh/go-homedir"
+)
+
+// configFileName is the file name for rat's XDG-compliant configuration
+const configFileName = "config.toml"
+
+// FilterProfile is a named filter command profile, e.g. "peco" or "fzf"
+type FilterProfile struct {
+	Command string            `toml:"command"`
+	Args    []string          `toml:"args"`
+	Env     map[string]string `toml:"env"`
+}
+
+// FileConfig is the structure of the rat config file
+type FileConfig struct {
+	Root          string                   `toml:"root"`
+	Roots         []string                 `toml:"roots"`
+	FilterProfile string                   `toml:"filter_profile"`
+	Filters       map[string]FilterProfile `toml:"filters"`
+}
+
+// envSlice renders Env as "KEY=VALUE" pairs suitable for exec.Cmd.Env
+func (p FilterProfile) envSlice() []string {
+	env := make([]string, 0, len(p.Env))
+	for k, v := range p.Env {
+		env = append(env, k+"="+v)
+	}
+	return env
+}
+
+// defaultFileConfig is the content `rat config init` writes out
+var defaultFileConfig = FileConfig{
+	FilterProfile: "peco",
+	Filters: map[string]FilterProfile{
+		"peco": {Command: "peco"},
+		"fzf":  {Command: "fzf"},
+		"sk":   {Command: "sk"},
+		"gum":  {Command: "gum", Args: []string{"choose"}},
+	},
+}
+
+// configFilePath resolves the rat config file path: $XDG_CONFIG_HOME/rat/config.toml,
+// falling back to ~/.config/rat/config.toml, then ~/.rat/config.toml
+func configFilePath() (string, error) {
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, NAME, configFileName), nil
+	}
+
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+
+	xdgDefault := filepath.Join(home, ".config", NAME, configFileName)
+	if fileExists(xdgDefault) {
+		return xdgDefault, nil
+	}
+
+	legacy := filepath.Join(home, ".rat", configFileName)
+	if fileExists(legacy) {
+		return legacy, nil
+	}
+
+	return xdgDefault, nil
+}
+
+// loadFileConfig reads the rat config file. a missing file is not an error;
+// it yields a zero-value FileConfig so callers fall through to env/defaults.
+func loadFileConfig() (*FileConfig, error) {
+	path, err := configFilePath()
+	if err != nil {
+		return nil, err
+	}
+	if !fileExists(path) {
+		return &FileConfig{}, nil
+	}
+
+	fc := new(FileConfig)
+	if _, err := toml.DecodeFile(path, fc); err != nil {
+		return nil, fmt.Errorf("failed to parse '%s': %s", path, err)
+	}
+	return fc, nil
+}
+
+// writeStarterFileConfig writes defaultFileConfig to the rat config path,
+// creating parent directories as needed. it refuses to clobber an existing
+// file.
+func writeStarterFileConfig() (string, error) {
+	path, err := configFilePath()
+	if err != nil {
+		return "", err
+	}
+	if fileExists(path) {
+		return "", fmt.Errorf("'%s' already exists", path)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if err := toml.NewEncoder(f).Encode(defaultFileConfig); err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// cacheDirName is the directory under the boilerplates root that holds
+// boilerplates fetched from a remote source
+const cacheDirName = ".cache"
+
+// isRemoteSource returns true if name looks like a URL or a
+// "host/user/repo" git shorthand rather than the name of a local boilerplate
+func isRemoteSource(name string) bool {
+	if strings.HasPrefix(name, "http://") || strings.HasPrefix(name, "https://") || strings.HasPrefix(name, "git@") {
+		return true
+	}
+	return strings.Count(name, "/") >= 2
+}
+
+// cacheKey derives a stable cache directory name for a remote source
+func cacheKey(source string) string {
+	sum := sha256.Sum256([]byte(source))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// fetchBoilerplate resolves a remote source to a local directory under
+// ratRoot/.cache, downloading or cloning it first if it isn't cached yet or
+// update is true.
+func fetchBoilerplate(ratRoot, source, checksum string, update bool) (string, error) {
+	cacheDir := filepath.Join(ratRoot, cacheDirName, cacheKey(source))
+
+	if update {
+		if err := os.RemoveAll(cacheDir); err != nil {
+			return "", err
+		}
+	}
+	if fileExists(cacheDir) {
+		return cacheDir, nil
+	}
+
+	if err := os.MkdirAll(filepath.Join(ratRoot, cacheDirName), 0755); err != nil {
+		return "", err
+	}
+
+	switch {
+	case strings.HasSuffix(source, ".tar.gz") || strings.HasSuffix(source, ".zip"):
+		return cacheDir, fetchArchive(source, cacheDir, checksum)
+	case strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "git@"):
+		return cacheDir, cloneGit(source, cacheDir)
+	default:
+		// shorthand, e.g. "github.com/user/repo"
+		return cacheDir, cloneGit("https://"+source+".git", cacheDir)
+	}
+}
+
+// cloneGit clones source into dst with a shallow checkout
+func cloneGit(source, dst string) error {
+	cmd := exec.Command("git", "clone", "--depth", "1", source, dst)
+	cmd.Stdout = ioutil.Discard
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to clone '%s': %s", source, err)
+	}
+	return nil
+}
+
+// fetchArchive downloads a tar.gz or zip archive and extracts it into dst,
+// verifying its sha256 checksum first when one is given
+func fetchArchive(source, dst, checksum string) error {
+	resp, err := http.Get(source)
+	if err != nil {
+		return fmt.Errorf("failed to fetch '%s': %s", source, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch '%s': %s", source, resp.Status)
+	}
+
+	tmp, err := ioutil.TempFile("", "rat-archive-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, h), resp.Body); err != nil {
+		return err
+	}
+	if checksum != "" {
+		if sum := hex.EncodeToString(h.Sum(nil)); sum != checksum {
+			return fmt.Errorf("checksum mismatch for '%s': got %s, want %s", source, sum, checksum)
+		}
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	if strings.HasSuffix(source, ".zip") {
+		return extractZip(tmp, tmp.Name(), dst)
+	}
+	return extractTarGz(tmp, dst)
+}
+
+// safeJoin joins dst and name, rejecting any entry whose resolved path
+// would escape dst (a "zip slip" path traversal via "../" or an absolute
+// path in an archive entry name)
+func safeJoin(dst, name string) (string, error) {
+	dst = filepath.Clean(dst)
+	target := filepath.Join(dst, name)
+	if target != dst && !strings.HasPrefix(target, dst+string(filepath.Separator)) {
+		return "", fmt.Errorf("illegal file path in archive: %s", name)
+	}
+	return target, nil
+}
+
+// extractTarGz extracts a gzip-compressed tarball into dst
+func extractTarGz(r io.Reader, dst string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target, err := safeJoin(dst, hdr.Name)
+		if err != nil {
+			return err
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, os.FileMode(hdr.Mode)); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := writeFile(target, tr, os.FileMode(hdr.Mode)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// extractZip extracts a zip archive into dst. archivePath is re-opened with
+// archive/zip, which needs to seek within the file.
+func extractZip(_ io.Reader, archivePath, dst string) error {
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		target, err := safeJoin(dst, f.Name)
+		if err != nil {
+			return err
+		}
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, f.Mode()); err != nil {
+				return err
+			}
+			continue
+		}
+
+		src, err := f.Open()
+		if err != nil {
+			return err
+		}
+		err = writeFile(target, src, f.Mode())
+		src.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeFile(target string, r io.Reader, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(f, r)
+	return err
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSafeJoinRejectsPathTraversal(t *testing.T) {
+	if _, err := safeJoin("/dst", "../escape.txt"); err == nil {
+		t.Fatal("expected an error for a path traversal entry, got nil")
+	}
+}
+
+func TestSafeJoinAllowsNestedPath(t *testing.T) {
+	target, err := safeJoin("/dst", "sub/file.txt")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want := filepath.Join("/dst", "sub/file.txt")
+	if target != want {
+		t.Fatalf("target = %q, want %q", target, want)
+	}
+}
+
+func TestExtractTarGzRejectsPathTraversal(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	body := []byte("evil")
+	if err := tw.WriteHeader(&tar.Header{Name: "../../etc/passwd", Size: int64(len(body)), Mode: 0644}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write(body); err != nil {
+		t.Fatal(err)
+	}
+	tw.Close()
+	gz.Close()
+
+	dst, err := ioutil.TempDir("", "rat-extract-dst-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dst)
+
+	if err := extractTarGz(&buf, dst); err == nil {
+		t.Fatal("expected an error extracting a tar with a path traversal entry, got nil")
+	}
+}
+
+func TestExtractTarGzWritesFiles(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	body := []byte("hello")
+	if err := tw.WriteHeader(&tar.Header{Name: "sub/file.txt", Size: int64(len(body)), Mode: 0644}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write(body); err != nil {
+		t.Fatal(err)
+	}
+	tw.Close()
+	gz.Close()
+
+	dst, err := ioutil.TempDir("", "rat-extract-dst-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dst)
+
+	if err := extractTarGz(&buf, dst); err != nil {
+		t.Fatalf("extractTarGz returned an error: %s", err)
+	}
+
+	got, err := ioutil.ReadFile(filepath.Join(dst, "sub/file.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "hello" {
+		t.Fatalf("content = %q, want %q", got, "hello")
+	}
+}
+
+func TestExtractTarGzRejectsCorruptArchive(t *testing.T) {
+	dst, err := ioutil.TempDir("", "rat-extract-dst-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dst)
+
+	if err := extractTarGz(bytes.NewReader([]byte("not a gzip stream")), dst); err == nil {
+		t.Fatal("expected an error extracting a corrupt tar.gz, got nil")
+	}
+}
+
+func writeTestZip(t *testing.T, path string, names []string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte("hi")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExtractZipRejectsPathTraversal(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "rat-extract-zip-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	archive := filepath.Join(tmpDir, "evil.zip")
+	writeTestZip(t, archive, []string{"../../etc/passwd"})
+
+	dst := filepath.Join(tmpDir, "dst")
+	if err := extractZip(nil, archive, dst); err == nil {
+		t.Fatal("expected an error extracting a zip with a path traversal entry, got nil")
+	}
+}
+
+func TestExtractZipWritesFiles(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "rat-extract-zip-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	archive := filepath.Join(tmpDir, "ok.zip")
+	writeTestZip(t, archive, []string{"sub/file.txt"})
+
+	dst := filepath.Join(tmpDir, "dst")
+	if err := extractZip(nil, archive, dst); err != nil {
+		t.Fatalf("extractZip returned an error: %s", err)
+	}
+
+	got, err := ioutil.ReadFile(filepath.Join(dst, "sub/file.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "hi" {
+		t.Fatalf("content = %q, want %q", got, "hi")
+	}
+}
+
+func TestFetchArchiveRejectsChecksumMismatch(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	tw.Close()
+	gz.Close()
+	body := buf.Bytes()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	dst, err := ioutil.TempDir("", "rat-fetch-dst-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dst)
+	os.RemoveAll(dst)
+
+	err = fetchArchive(srv.URL+"/blpl.tar.gz", dst, "0000000000000000000000000000000000000000000000000000000000000000")
+	if err == nil {
+		t.Fatal("expected a checksum mismatch error, got nil")
+	}
+}
+
+func TestFetchArchiveRejectsCorruptArchive(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("not a valid archive"))
+	}))
+	defer srv.Close()
+
+	dst, err := ioutil.TempDir("", "rat-fetch-dst-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dst)
+	os.RemoveAll(dst)
+
+	if err := fetchArchive(srv.URL+"/blpl.tar.gz", dst, ""); err == nil {
+		t.Fatal("expected an error fetching a corrupt archive, got nil")
+	}
+}
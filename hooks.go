@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// HookCommand is a single pre/post generation hook. it is either a literal
+// shell command (Run) or a named macro (Macro) with Prefix/Suffix arguments.
+type HookCommand struct {
+	Run    string `yaml:"run"`
+	Macro  string `yaml:"macro"`
+	Prefix string `yaml:"prefix"`
+	Suffix string `yaml:"suffix"`
+}
+
+// macroCommands maps a hook macro name to the shell command it expands to.
+// %s is replaced with the hook's prefix and suffix, space-joined.
+var macroCommands = map[string]string{
+	"git-init":    "git init %s",
+	"go-mod-init": "go mod init %s",
+	"npm-install": "npm install %s",
+	"gofmt":       "gofmt -w %s",
+}
+
+// command resolves the hook to its final shell command line
+func (h HookCommand) command() (string, error) {
+	if h.Run != "" {
+		return h.Run, nil
+	}
+
+	tmpl, ok := macroCommands[h.Macro]
+	if !ok {
+		return "", fmt.Errorf("unknown hook macro '%s'", h.Macro)
+	}
+	args := strings.TrimSpace(h.Prefix + " " + h.Suffix)
+	return strings.TrimSpace(fmt.Sprintf(tmpl, args)), nil
+}
+
+// runHooks executes hooks in order with dir as their working directory,
+// aborting on the first non-zero exit. with dryRun set, it only prints the
+// commands it would run.
+func runHooks(hooks []HookCommand, dir string, env []string, dryRun bool, stdout, stderr io.Writer) error {
+	for _, h := range hooks {
+		cmdLine, err := h.command()
+		if err != nil {
+			return err
+		}
+
+		if dryRun {
+			fmt.Fprintf(stdout, "dry-run: %s\n", cmdLine)
+			continue
+		}
+
+		if err := runHook(cmdLine, dir, env, stdout, stderr); err != nil {
+			return fmt.Errorf("hook '%s' failed: %s", cmdLine, err)
+		}
+	}
+	return nil
+}
+
+func runHook(cmdLine, dir string, env []string, stdout, stderr io.Writer) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/c", cmdLine)
+	} else {
+		cmd = exec.Command("sh", "-c", cmdLine)
+	}
+	cmd.Dir = dir
+	cmd.Env = env
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return cmd.Run()
+}
+
+// hookEnv builds the environment passed to hook commands: the process
+// environment plus rat-specific and user-defined template variables
+func hookEnv(projectPath string, vars map[string]string) []string {
+	env := os.Environ()
+	env = append(env, "RAT_PROJECT_PATH="+projectPath)
+	if name, ok := vars["ProjectName"]; ok {
+		env = append(env, "RAT_PROJECT_NAME="+name)
+	}
+	for k, v := range vars {
+		env = append(env, fmt.Sprintf("RAT_VAR_%s=%s", k, v))
+	}
+	return env
+}
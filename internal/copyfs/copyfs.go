@@ -0,0 +1,282 @@
+// Package copyfs copies a directory tree from one path to another,
+// optionally renaming and rewriting entries along the way, with file copies
+// parallelized across a bounded worker pool.
+package copyfs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// RenameFunc renders a source-relative path into its destination name. it is
+// called once per entry, for both directories and files.
+type RenameFunc func(relPath string) (string, error)
+
+// TransformFunc rewrites a file's contents before they are written to the
+// destination.
+type TransformFunc func(relPath string, content []byte) ([]byte, error)
+
+// SkipFunc reports whether relPath should be omitted from the destination
+// entirely.
+type SkipFunc func(relPath string) bool
+
+// OnErrorFunc is called for every entry that fails to copy. when it returns
+// true, the entry is skipped and Copy continues; otherwise Copy aborts and
+// rolls back everything it has written so far. a nil OnErrorFunc aborts on
+// the first error.
+type OnErrorFunc func(relPath string, err error) (skip bool)
+
+// Copier copies the tree rooted at Src into Dst
+type Copier struct {
+	Src string
+	Dst string
+
+	// Force allows copying into a Dst that already has entries in it
+	Force bool
+
+	// FollowSymlinks copies a symlink's target instead of recreating the
+	// link itself at the destination
+	FollowSymlinks bool
+
+	// Workers bounds how many files are copied concurrently. <= 0 means
+	// runtime.NumCPU().
+	Workers int
+
+	Rename    RenameFunc
+	Transform TransformFunc
+	Skip      SkipFunc
+	OnError   OnErrorFunc
+}
+
+type entry struct {
+	relPath string
+	dstPath string
+	info    os.FileInfo
+}
+
+// Copy walks Src and copies it into Dst, creating Dst if necessary
+func (c *Copier) Copy() error {
+	if err := c.checkDst(); err != nil {
+		return err
+	}
+
+	entries, err := c.collect()
+	if err != nil {
+		return err
+	}
+
+	var (
+		written []string
+		files   []entry
+	)
+	for _, e := range entries {
+		switch {
+		case e.info.Mode()&os.ModeSymlink != 0:
+			if err := c.copySymlink(e); err != nil {
+				return c.rollback(written, fmt.Errorf("failed to copy '%s': %s", e.relPath, err))
+			}
+			written = append(written, e.dstPath)
+		case e.info.IsDir():
+			if err := os.MkdirAll(e.dstPath, e.info.Mode()); err != nil {
+				return c.rollback(written, err)
+			}
+			written = append(written, e.dstPath)
+		default:
+			files = append(files, e)
+		}
+	}
+
+	written, err = c.copyFiles(files, written)
+	if err != nil {
+		return c.rollback(written, err)
+	}
+	return nil
+}
+
+// checkDst creates Dst if it doesn't exist yet, and refuses to proceed if it
+// already has entries and Force isn't set
+func (c *Copier) checkDst() error {
+	dirs, err := ioutil.ReadDir(c.Dst)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return os.MkdirAll(c.Dst, 0755)
+		}
+		return err
+	}
+	if len(dirs) > 0 && !c.Force {
+		return fmt.Errorf("destination '%s' is not empty", c.Dst)
+	}
+	return nil
+}
+
+// collect walks Src once, resolving each entry's destination name
+func (c *Copier) collect() ([]entry, error) {
+	return c.walk(c.Src, "")
+}
+
+// walk walks root, reporting entries relative to root with relPrefix
+// prepended. it is called recursively, rooted at a symlinked directory's
+// resolved target, so that FollowSymlinks actually copies a symlinked
+// directory's contents instead of the empty directory filepath.Walk would
+// otherwise see (filepath.Walk never descends into a path it lstats as a
+// symlink).
+func (c *Copier) walk(root, relPrefix string) ([]entry, error) {
+	var entries []entry
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel := strings.TrimPrefix(strings.TrimPrefix(path, root), string(filepath.Separator))
+		switch {
+		case rel == "" && relPrefix == "":
+			return nil
+		case rel == "":
+			rel = relPrefix
+		default:
+			rel = filepath.Join(relPrefix, rel)
+		}
+
+		if c.Skip != nil && c.Skip(rel) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 && c.FollowSymlinks {
+			target, err := os.Stat(path)
+			if err != nil {
+				return fmt.Errorf("failed to resolve symlink '%s': %s", rel, err)
+			}
+			if target.IsDir() {
+				resolved, err := filepath.EvalSymlinks(path)
+				if err != nil {
+					return fmt.Errorf("failed to resolve symlink '%s': %s", rel, err)
+				}
+				sub, err := c.walk(resolved, rel)
+				if err != nil {
+					return err
+				}
+				entries = append(entries, sub...)
+				return nil
+			}
+			info = target
+		}
+
+		name := rel
+		if c.Rename != nil {
+			renamed, err := c.Rename(rel)
+			if err != nil {
+				return fmt.Errorf("failed to render name '%s': %s", rel, err)
+			}
+			name = renamed
+		}
+
+		entries = append(entries, entry{relPath: rel, dstPath: filepath.Join(c.Dst, name), info: info})
+		return nil
+	})
+	return entries, err
+}
+
+// copyFiles copies regular files concurrently across a bounded worker pool,
+// returning the destination paths it wrote and the first error encountered
+func (c *Copier) copyFiles(files []entry, written []string) ([]string, error) {
+	workers := c.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		sem      = make(chan struct{}, workers)
+		firstErr error
+	)
+
+	for _, e := range files {
+		e := e
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			err := c.copyFile(e)
+			if err == nil {
+				mu.Lock()
+				written = append(written, e.dstPath)
+				mu.Unlock()
+				return
+			}
+
+			if c.OnError != nil && c.OnError(e.relPath, err) {
+				return
+			}
+			mu.Lock()
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to copy '%s': %s", e.relPath, err)
+			}
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	return written, firstErr
+}
+
+// copySymlink recreates a symlink at e.dstPath, or, when FollowSymlinks is
+// set, copies whatever it points to
+func (c *Copier) copySymlink(e entry) error {
+	srcPath := filepath.Join(c.Src, e.relPath)
+	if !c.FollowSymlinks {
+		target, err := os.Readlink(srcPath)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, e.dstPath)
+	}
+
+	info, err := os.Stat(srcPath)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return os.MkdirAll(e.dstPath, info.Mode())
+	}
+	return c.copyFile(entry{relPath: e.relPath, dstPath: e.dstPath, info: info})
+}
+
+// copyFile copies a single regular file, preserving its mode and mtime
+func (c *Copier) copyFile(e entry) error {
+	b, err := ioutil.ReadFile(filepath.Join(c.Src, e.relPath))
+	if err != nil {
+		return err
+	}
+
+	if c.Transform != nil {
+		b, err = c.Transform(e.relPath, b)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := ioutil.WriteFile(e.dstPath, b, e.info.Mode()); err != nil {
+		return err
+	}
+	return os.Chtimes(e.dstPath, e.info.ModTime(), e.info.ModTime())
+}
+
+// rollback removes everything Copy wrote, in reverse order, before
+// returning cause
+func (c *Copier) rollback(written []string, cause error) error {
+	for i := len(written) - 1; i >= 0; i-- {
+		os.RemoveAll(written[i])
+	}
+	return cause
+}
@@ -0,0 +1,177 @@
+package copyfs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCopySymlinkLoop(t *testing.T) {
+	src, err := ioutil.TempDir("", "copyfs-src-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	loop := filepath.Join(src, "loop")
+	if err := os.Symlink(loop, loop); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(src, "..", "copyfs-dst-loop")
+	defer os.RemoveAll(dst)
+
+	c := &Copier{Src: src, Dst: dst, FollowSymlinks: true}
+	if err := c.Copy(); err == nil {
+		t.Fatal("expected an error following a self-referential symlink, got nil")
+	}
+}
+
+func TestCopyPreservesSymlinkByDefault(t *testing.T) {
+	src, err := ioutil.TempDir("", "copyfs-src-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	target := filepath.Join(src, "target.txt")
+	if err := ioutil.WriteFile(target, []byte("hi"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(src, "link.txt")
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+
+	dst, err := ioutil.TempDir("", "copyfs-dst-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dst)
+	os.RemoveAll(dst) // Copy must recreate an empty Dst
+
+	c := &Copier{Src: src, Dst: dst}
+	if err := c.Copy(); err != nil {
+		t.Fatalf("Copy() returned an error: %s", err)
+	}
+
+	fi, err := os.Lstat(filepath.Join(dst, "link.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode()&os.ModeSymlink == 0 {
+		t.Fatal("expected link.txt to remain a symlink")
+	}
+}
+
+func TestCopyFollowsSymlinkedDirectory(t *testing.T) {
+	src, err := ioutil.TempDir("", "copyfs-src-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	realDir := filepath.Join(src, "realdir")
+	if err := os.Mkdir(realDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(realDir, "file.txt"), []byte("hi"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	linkDir := filepath.Join(src, "linkdir")
+	if err := os.Symlink(realDir, linkDir); err != nil {
+		t.Fatal(err)
+	}
+
+	dst, err := ioutil.TempDir("", "copyfs-dst-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dst)
+	os.RemoveAll(dst) // Copy must recreate an empty Dst
+
+	c := &Copier{Src: src, Dst: dst, FollowSymlinks: true}
+	if err := c.Copy(); err != nil {
+		t.Fatalf("Copy() returned an error: %s", err)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dst, "linkdir", "file.txt"))
+	if err != nil {
+		t.Fatalf("expected linkdir/file.txt to be copied: %s", err)
+	}
+	if string(b) != "hi" {
+		t.Fatalf("got content %q, want %q", b, "hi")
+	}
+}
+
+func TestCopyPermissionError(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("unreadable files are still readable as root")
+	}
+
+	src, err := ioutil.TempDir("", "copyfs-src-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	unreadable := filepath.Join(src, "unreadable.txt")
+	if err := ioutil.WriteFile(unreadable, []byte("secret"), 0000); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(src, "..", "copyfs-dst-perm")
+	defer os.RemoveAll(dst)
+
+	c := &Copier{Src: src, Dst: dst}
+	if err := c.Copy(); err == nil {
+		t.Fatal("expected a permission error, got nil")
+	}
+}
+
+func TestCopyRollsBackOnPartialFailure(t *testing.T) {
+	src, err := ioutil.TempDir("", "copyfs-src-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	if err := ioutil.WriteFile(filepath.Join(src, "ok.txt"), []byte("ok"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(src, "bad.txt"), []byte("bad"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dst, err := ioutil.TempDir("", "copyfs-dst-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dst)
+	os.RemoveAll(dst)
+
+	c := &Copier{
+		Src: src,
+		Dst: dst,
+		Transform: func(relPath string, content []byte) ([]byte, error) {
+			if relPath == "bad.txt" {
+				return nil, errFakeTransform
+			}
+			return content, nil
+		},
+	}
+	if err := c.Copy(); err == nil {
+		t.Fatal("expected Copy to fail on bad.txt")
+	}
+
+	if _, err := os.Stat(filepath.Join(dst, "ok.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected ok.txt to be rolled back, stat err = %v", err)
+	}
+}
+
+var errFakeTransform = &copyTestError{"forced transform failure"}
+
+type copyTestError struct{ msg string }
+
+func (e *copyTestError) Error() string { return e.msg }
@@ -15,6 +15,14 @@ const (
 	exitCodeError
 )
 
+// these are set at build time via:
+// go build -ldflags "-X main.version=... -X main.commit=... -X main.buildDate=..."
+var (
+	version   = "dev"
+	commit    = "none"
+	buildDate = "unknown"
+)
+
 func main() {
 	cli, err := newCLI(os.Stdout, os.Stderr, os.Args)
 	if err != nil {
@@ -30,9 +38,20 @@ NAME:
 
 USAGE:
     rat [GLOBAL-OPTIONS] [<boilerplate-name>] <project-name>
+    rat config init
+    rat config show
+    rat version
 
 GLOBAL-OPTIONS:
-    --list, -l     Show boilerplate list
-    --version, -v  Show version
-    --help, -h     Show this message
+    --list, -l            Show boilerplate list
+    --var key=value       Set a template variable (repeatable)
+    --no-hooks            Skip running the boilerplate's pre/post hooks
+    --dry-run             Print hook commands instead of running them
+    --add url             Fetch a remote boilerplate without generating
+    --update name         Refresh a cached remote boilerplate
+    --checksum sha256     Verify a remote boilerplate's archive against this sha256 sum
+    --force               Allow generating into a non-empty project directory
+    --filter-profile name Select a named filter profile from the config file
+    --version, -v         Show version
+    --help, -h            Show this message
 `
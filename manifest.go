@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// manifestFileName is the boilerplate manifest placed at a boilerplate root
+const manifestFileName = ".rat.yml"
+
+// Manifest describes a boilerplate's template variables, the files that
+// should be copied byte-for-byte instead of being treated as templates, and
+// the hooks to run around generation
+type Manifest struct {
+	Variables []ManifestVariable `yaml:"variables"`
+	Ignore    []string           `yaml:"ignore"`
+	PreHooks  []HookCommand      `yaml:"pre_hooks"`
+	PostHooks []HookCommand      `yaml:"post_hooks"`
+}
+
+// ManifestVariable is a single template variable declaration
+type ManifestVariable struct {
+	Name    string `yaml:"name"`
+	Prompt  string `yaml:"prompt"`
+	Default string `yaml:"default"`
+	Pattern string `yaml:"pattern"`
+}
+
+// loadManifest reads the manifest file at the boilerplate root.
+// a boilerplate without a manifest is valid, so a missing file yields an
+// empty, non-nil Manifest rather than an error.
+func loadManifest(boilerplateRoot string) (*Manifest, error) {
+	path := filepath.Join(boilerplateRoot, manifestFileName)
+	if !fileExists(path) {
+		return &Manifest{}, nil
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read '%s': %s", path, err)
+	}
+
+	m := new(Manifest)
+	if err := yaml.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("failed to parse '%s': %s", path, err)
+	}
+	return m, nil
+}
+
+// resolveVars merges the manifest's declared variables with values already
+// supplied (from -var flags or RAT_VARS). anything still missing is prompted
+// for interactively on errStream.
+func (m *Manifest) resolveVars(supplied map[string]string, in io.Reader, errStream io.Writer) (map[string]string, error) {
+	vars := make(map[string]string, len(supplied))
+	for k, v := range supplied {
+		vars[k] = v
+	}
+
+	scanner := bufio.NewScanner(in)
+	for _, v := range m.Variables {
+		if value, ok := vars[v.Name]; ok {
+			if err := v.validate(value); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		value, err := v.ask(scanner, errStream)
+		if err != nil {
+			return nil, err
+		}
+		vars[v.Name] = value
+	}
+
+	return vars, nil
+}
+
+// ask prompts for the variable's value on errStream and reads a line from in.
+// an empty line falls back to the variable's default.
+func (v *ManifestVariable) ask(scanner *bufio.Scanner, errStream io.Writer) (string, error) {
+	prompt := v.Prompt
+	if prompt == "" {
+		prompt = v.Name
+	}
+	if v.Default != "" {
+		fmt.Fprintf(errStream, "%s [%s]: ", prompt, v.Default)
+	} else {
+		fmt.Fprintf(errStream, "%s: ", prompt)
+	}
+
+	value := v.Default
+	if scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			value = line
+		}
+	}
+
+	if err := v.validate(value); err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+// validate checks value against the variable's pattern, when one is set
+func (v *ManifestVariable) validate(value string) error {
+	if v.Pattern == "" {
+		return nil
+	}
+	re, err := regexp.Compile(v.Pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern for variable '%s': %s", v.Name, err)
+	}
+	if !re.MatchString(value) {
+		return fmt.Errorf("'%s' does not match pattern '%s' for variable '%s'", value, v.Pattern, v.Name)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"text/template"
+)
+
+// defaultIgnorePatterns are glob patterns for files that are always copied
+// byte-for-byte, even without a manifest `ignore` entry
+var defaultIgnorePatterns = []string{
+	"*.png", "*.jpg", "*.jpeg", "*.gif", "*.ico",
+	"*.woff", "*.woff2", "*.ttf", "*.eot",
+	"*.zip", "*.tar.gz",
+}
+
+// renderTemplate expands Go template syntax in src using vars
+func renderTemplate(name string, src []byte, vars map[string]string) ([]byte, error) {
+	tmpl, err := template.New(name).Parse(string(src))
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// renderName expands template expressions found in a file or directory name,
+// e.g. "{{.ProjectName}}.go"
+func renderName(name string, vars map[string]string) (string, error) {
+	tmpl, err := template.New("name").Parse(name)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// isIgnoredFile returns true if name should be copied byte-for-byte instead
+// of being treated as a template
+func isIgnoredFile(name string, patterns []string) bool {
+	for _, p := range append(defaultIgnorePatterns, patterns...) {
+		if ok, _ := filepath.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
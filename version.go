@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// versionBanner renders the verbose `rat version` banner: semver, build
+// metadata injected via -ldflags, the Go toolchain used to build rat, and a
+// snapshot of the resolved runtime config.
+func versionBanner(cfg *Config) string {
+	boilerplateCount := 0
+	if list, err := cfg.blplList(); err == nil {
+		boilerplateCount = len(list)
+	}
+
+	return fmt.Sprintf(
+		`rat %s
+commit:        %s
+build date:    %s
+go version:    %s
+os/arch:       %s/%s
+rat root:      %s
+filter:        %s
+boilerplates:  %d
+`,
+		version, commit, buildDate, runtime.Version(), runtime.GOOS, runtime.GOARCH,
+		cfg.root, cfg.filterProfile, boilerplateCount,
+	)
+}
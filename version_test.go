@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRunShowsShortVersion(t *testing.T) {
+	oldVersion := version
+	version = "1.2.3"
+	defer func() { version = oldVersion }()
+
+	var stdout, stderr bytes.Buffer
+	cli := &CLI{
+		stdout:   &stdout,
+		stderr:   &stderr,
+		fatalLog: newFatalLogger(&stderr),
+		cfg:      &Config{showVersion: true},
+	}
+
+	if code := cli.run(); code != exitCodeOK {
+		t.Fatalf("run() = %d, want %d", code, exitCodeOK)
+	}
+
+	want := "rat version 1.2.3\n"
+	if got := stdout.String(); got != want {
+		t.Fatalf("stdout = %q, want %q", got, want)
+	}
+}
+
+func TestRunShowsVerboseVersion(t *testing.T) {
+	oldVersion, oldCommit, oldBuildDate := version, commit, buildDate
+	version, commit, buildDate = "1.2.3", "abcdef0", "2026-01-01"
+	defer func() { version, commit, buildDate = oldVersion, oldCommit, oldBuildDate }()
+
+	var stdout, stderr bytes.Buffer
+	cli := &CLI{
+		stdout:   &stdout,
+		stderr:   &stderr,
+		fatalLog: newFatalLogger(&stderr),
+		cfg: &Config{
+			verboseVersion: true,
+			root:           "/tmp/does-not-exist",
+			filterProfile:  "peco",
+		},
+	}
+
+	if code := cli.run(); code != exitCodeOK {
+		t.Fatalf("run() = %d, want %d", code, exitCodeOK)
+	}
+
+	out := stdout.String()
+	for _, want := range []string{
+		"rat 1.2.3",
+		"commit:        abcdef0",
+		"build date:    2026-01-01",
+		"rat root:      /tmp/does-not-exist",
+		"filter:        peco",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("output missing %q, got:\n%s", want, out)
+		}
+	}
+}